@@ -3,12 +3,16 @@ package node
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/prysmaticlabs/prysm/v3/beacon-chain/core/signing"
+	"github.com/rocket-pool/rocketpool-go/core"
 	tndao "github.com/rocket-pool/rocketpool-go/dao/trustednode"
 	"github.com/rocket-pool/rocketpool-go/minipool"
 	"github.com/rocket-pool/rocketpool-go/network"
@@ -18,8 +22,9 @@ import (
 	tnsettings "github.com/rocket-pool/rocketpool-go/settings/trustednode"
 	rptypes "github.com/rocket-pool/rocketpool-go/types"
 	"github.com/rocket-pool/rocketpool-go/utils"
+	"github.com/rocket-pool/rocketpool-go/utils/batch"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
 	"github.com/urfave/cli"
-	"golang.org/x/sync/errgroup"
 
 	prdeposit "github.com/prysmaticlabs/prysm/v3/contracts/deposit"
 	ethpb "github.com/prysmaticlabs/prysm/v3/proto/prysm/v1alpha1"
@@ -87,167 +92,123 @@ func canNodeDeposit(c *cli.Context, amountWei *big.Int, minNodeFee float64, salt
 		salt.SetUint64(nonce)
 	}
 
-	// Data
-	var wg1 errgroup.Group
-	var isTrusted bool
-	var minipoolCount uint64
-	var minipoolLimit uint64
-	var minipoolAddress common.Address
-
-	// Check node balance
-	wg1.Go(func() error {
-		ethBalanceWei, err := ec.BalanceAt(context.Background(), nodeAccount.Address, nil)
-		if err == nil {
-			response.InsufficientBalance = (amountWei.Cmp(ethBalanceWei) > 0)
-		}
-		return err
-	})
-
-	// Check node deposits are enabled
-	wg1.Go(func() error {
-		depositEnabled, err := protocol.GetNodeDepositEnabled(rp, nil)
-		if err == nil {
-			response.DepositDisabled = !depositEnabled
-		}
-		return err
-	})
-
-	// Get trusted status
-	wg1.Go(func() error {
-		var err error
-		isTrusted, err = tndao.GetMemberExists(rp, nodeAccount.Address, nil)
-		return err
-	})
-
-	// Get node staking information
-	wg1.Go(func() error {
-		var err error
-		minipoolCount, err = minipool.GetNodeMinipoolCount(rp, nodeAccount.Address, nil)
-		return err
-	})
-	wg1.Go(func() error {
-		var err error
-		minipoolLimit, err = node.GetNodeMinipoolLimit(rp, nodeAccount.Address, nil)
-		return err
-	})
-
-	// Get consensus status
-	wg1.Go(func() error {
-		var err error
-		inConsensus, err := network.InConsensus(rp, nil)
-		response.InConsensus = inConsensus
-		return err
-	})
-
-	// Get gas estimate
-	wg1.Go(func() error {
-		opts, err := w.GetNodeAccountTransactor()
-		if err != nil {
-			return err
-		}
-		opts.Value = amountWei
-
-		// Get the deposit type
-		depositType, err := node.GetDepositType(rp, amountWei, nil)
-		if err != nil {
-			return err
-		}
-
-		// Get the next validator key
-		validatorKey, err := w.GetNextValidatorKey()
-		if err != nil {
-			return err
-		}
+	// Query node balance, DAO/protocol settings and staking limits in a single multicall round-trip
+	// instead of one eth_call per field
+	ethBalanceField := core.NewSimpleField[*big.Int]()
+	depositEnabledField := core.NewSimpleField[bool]()
+	memberExistsField := core.NewSimpleField[bool]()
+	minipoolCountField := core.NewFormattedUint256Field[uint64]()
+	minipoolLimitField := core.NewFormattedUint256Field[uint64]()
+	inConsensusField := core.NewSimpleField[bool]()
+	unbondedMinipoolCountField := core.NewFormattedUint256Field[uint64]()
+	unbondedMinipoolsMaxField := core.NewFormattedUint256Field[uint64]()
 
-		// Get the next minipool address and withdrawal credentials
-		minipoolAddress, err = utils.GenerateAddress(rp, nodeAccount.Address, depositType, salt, nil, nil)
-		if err != nil {
-			return err
-		}
-		withdrawalCredentials, err := minipool.GetMinipoolWithdrawalCredentials(rp, minipoolAddress, nil)
-		if err != nil {
-			return err
-		}
-
-		// Get validator deposit data and associated parameters
-		depositData, depositDataRoot, err := validator.GetDepositData(validatorKey, withdrawalCredentials, eth2Config)
-		if err != nil {
-			return err
-		}
-		pubKey := rptypes.BytesToValidatorPubkey(depositData.PublicKey)
-		signature := rptypes.BytesToValidatorSignature(depositData.Signature)
-
-		// Do a final sanity check
-		err = validateDepositInfo(eth2Config, uint64(validator.DepositAmount), pubKey, withdrawalCredentials, signature)
-		if err != nil {
-			return fmt.Errorf("Your deposit failed the validation safety check: %w\n"+
-				"For your safety, this deposit will not be submitted and your ETH will not be staked.\n"+
-				"PLEASE REPORT THIS TO THE ROCKET POOL DEVELOPERS and include the following information:\n"+
-				"\tDomain Type: 0x%s\n"+
-				"\tGenesis Fork Version: 0x%s\n"+
-				"\tGenesis Validator Root: 0x%s\n"+
-				"\tDeposit Amount: %s gwei\n"+
-				"\tValidator Pubkey: %s\n"+
-				"\tWithdrawal Credentials: %s\n"+
-				"\tSignature: %s\n",
-				err,
-				hex.EncodeToString(eth2types.DomainDeposit[:]),
-				hex.EncodeToString(eth2Config.GenesisForkVersion),
-				hex.EncodeToString(eth2types.ZeroGenesisValidatorsRoot),
-				uint64(validator.DepositAmount),
-				pubKey.Hex(),
-				withdrawalCredentials.Hex(),
-				signature.Hex(),
-			)
-		}
-
-		// Run the deposit gas estimator
-		gasInfo, err := node.EstimateDepositGas(rp, minNodeFee, pubKey, signature, depositDataRoot, salt, minipoolAddress, opts)
-		if err == nil {
-			response.GasInfo = gasInfo
-		}
-		return err
-	})
-
-	// Wait for data
-	if err := wg1.Wait(); err != nil {
+	mc, err := batch.NewMultiCaller(ec, rp.MulticallAddress)
+	if err != nil {
 		return nil, err
 	}
+	err = eth.QueryAllFields(mc,
+		eth.EthBalance(ethBalanceField, nodeAccount.Address),
+		protocol.NodeDepositEnabled(rp, depositEnabledField),
+		tndao.MemberExists(rp, nodeAccount.Address, memberExistsField),
+		minipool.NodeMinipoolCount(rp, nodeAccount.Address, minipoolCountField),
+		node.NodeMinipoolLimit(rp, nodeAccount.Address, minipoolLimitField),
+		network.InConsensus(rp, inConsensusField),
+		tndao.MemberUnbondedValidatorCount(rp, nodeAccount.Address, unbondedMinipoolCountField),
+		tnsettings.MinipoolUnbondedMax(rp, unbondedMinipoolsMaxField),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying node deposit checks: %w", err)
+	}
+
+	response.InsufficientBalance = (amountWei.Cmp(ethBalanceField.Get()) > 0)
+	response.DepositDisabled = !depositEnabledField.Get()
+	response.InConsensus = inConsensusField.Get()
+	isTrusted := memberExistsField.Get()
+	minipoolCount := minipoolCountField.Get()
+	minipoolLimit := minipoolLimitField.Get()
+	unbondedMinipoolCount := unbondedMinipoolCountField.Get()
+	unbondedMinipoolsMax := unbondedMinipoolsMaxField.Get()
 
 	// Check data
 	response.InsufficientRplStake = (minipoolCount >= minipoolLimit)
-	response.MinipoolAddress = minipoolAddress
 	response.InvalidAmount = (!isTrusted && amountIsZero)
 
 	// Check oracle node unbonded minipool limit
 	if isTrusted && amountIsZero {
+		response.UnbondedMinipoolsAtMax = (unbondedMinipoolCount >= unbondedMinipoolsMax)
+	}
 
-		// Data
-		var wg2 errgroup.Group
-		var unbondedMinipoolCount uint64
-		var unbondedMinipoolsMax uint64
-
-		// Get unbonded minipool details
-		wg2.Go(func() error {
-			var err error
-			unbondedMinipoolCount, err = tndao.GetMemberUnbondedValidatorCount(rp, nodeAccount.Address, nil)
-			return err
-		})
-		wg2.Go(func() error {
-			var err error
-			unbondedMinipoolsMax, err = tnsettings.GetMinipoolUnbondedMax(rp, nil)
-			return err
-		})
-
-		// Wait for data
-		if err := wg2.Wait(); err != nil {
-			return nil, err
-		}
+	// Get the gas estimate - this still needs a sequential round trip: validator key -> withdrawal
+	// credentials -> deposit signature -> estimateGas, so it isn't a candidate for the multicall batch
+	var minipoolAddress common.Address
+	opts, err := w.GetNodeAccountTransactor()
+	if err != nil {
+		return nil, err
+	}
+	opts.Value = amountWei
 
-		// Check unbonded minipool limit
-		response.UnbondedMinipoolsAtMax = (unbondedMinipoolCount >= unbondedMinipoolsMax)
+	// Get the deposit type
+	depositType, err := node.GetDepositType(rp, amountWei, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the next validator key
+	validatorKey, err := w.GetNextValidatorKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the next minipool address and withdrawal credentials
+	minipoolAddress, err = utils.GenerateAddress(rp, nodeAccount.Address, depositType, salt, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	withdrawalCredentials, err := minipool.GetMinipoolWithdrawalCredentials(rp, minipoolAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get validator deposit data and associated parameters
+	depositData, depositDataRoot, err := validator.GetDepositData(validatorKey, withdrawalCredentials, eth2Config)
+	if err != nil {
+		return nil, err
+	}
+	pubKey := rptypes.BytesToValidatorPubkey(depositData.PublicKey)
+	signature := rptypes.BytesToValidatorSignature(depositData.Signature)
+
+	// Do a final sanity check
+	err = validateDepositInfo(eth2Config, uint64(validator.DepositAmount), pubKey, withdrawalCredentials, signature)
+	if err != nil {
+		return nil, fmt.Errorf("Your deposit failed the validation safety check: %w\n"+
+			"For your safety, this deposit will not be submitted and your ETH will not be staked.\n"+
+			"PLEASE REPORT THIS TO THE ROCKET POOL DEVELOPERS and include the following information:\n"+
+			"\tDomain Type: 0x%s\n"+
+			"\tGenesis Fork Version: 0x%s\n"+
+			"\tGenesis Validator Root: 0x%s\n"+
+			"\tDeposit Amount: %d gwei\n"+
+			"\tValidator Pubkey: %s\n"+
+			"\tWithdrawal Credentials: %s\n"+
+			"\tSignature: %s\n",
+			err,
+			hex.EncodeToString(eth2types.DomainDeposit[:]),
+			hex.EncodeToString(eth2Config.GenesisForkVersion),
+			hex.EncodeToString(eth2types.ZeroGenesisValidatorsRoot),
+			uint64(validator.DepositAmount),
+			pubKey.Hex(),
+			withdrawalCredentials.Hex(),
+			signature.Hex(),
+		)
+	}
 
+	// Run the deposit gas estimator
+	gasInfo, err := node.EstimateDepositGas(rp, minNodeFee, pubKey, signature, depositDataRoot, salt, minipoolAddress, opts)
+	if err != nil {
+		return nil, err
 	}
+	response.GasInfo = gasInfo
+	response.MinipoolAddress = minipoolAddress
 
 	// Update & return response
 	response.CanDeposit = !(response.InsufficientBalance || response.InsufficientRplStake || response.InvalidAmount || response.UnbondedMinipoolsAtMax || response.DepositDisabled || !response.InConsensus)
@@ -255,7 +216,7 @@ func canNodeDeposit(c *cli.Context, amountWei *big.Int, minNodeFee float64, salt
 
 }
 
-func nodeDeposit(c *cli.Context, amountWei *big.Int, minNodeFee float64, salt *big.Int, submit bool) (*api.NodeDepositResponse, error) {
+func nodeDeposit(c *cli.Context, amountWei *big.Int, minNodeFee float64, salt *big.Int, submit bool, dkgDepositDataPath string) (*api.NodeDepositResponse, error) {
 
 	// Get services
 	if err := services.RequireNodeRegistered(c); err != nil {
@@ -337,57 +298,7 @@ func nodeDeposit(c *cli.Context, amountWei *big.Int, minNodeFee float64, salt *b
 		return nil, err
 	}
 
-	dkg := false
-	// if dkg {
-	// 	// 1. LOAD KEYS GENERATED BY CHARON DKG
-	// 	// 2. STORE KEYSTORES IN ROCKETPOOL'S FORMAT, IE, ONE FOR EACH CLIENT
-	// } else {
-	// 	// Create and save a new validator key
-	// 	validatorKey, err := w.CreateValidatorKey()
-	// 	if err != nil {
-	// 		return nil, err
-	// 	}
-	// }
-
-	// THIS IS A NEW ROCKETPOOL COMMAND TO GET WITHDRAWAL CREDENTIALS.
-	// NOTE THAT MINIPOOL ADDRESS IS DETERMINED DETERMINISTICALLY USING THE CREATE2 OPCODE.
-	// ONLY NODE ACCOUNT ADDRESS AND SALT ARE REQUIRED.
-	// rocketpool node withdrawal-credentials
-	// getWithdrawalCredentials := func() (common.Hash, error) {
-	// 	minipoolAddress, err := utils.GenerateAddress(rp, nodeAccount.Address, depositType, salt, nil, nil)
-	// 	if err != nil {
-	// 		return common.Hash{}, err
-	// 	}
-	// 	withdrawalCredentials, err := minipool.GetMinipoolWithdrawalCredentials(rp, minipoolAddress, nil)
-	// 	if err != nil {
-	// 		return common.Hash{}, err
-	// 	}
-	//
-	// 	return withdrawalCredentials, nil
-	// }
-	// creds, err := getWithdrawalCredentials()
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// fmt.Println("withdrawal-credentials", creds)
-
-	// Create and save a new validator key
-	validatorKey, err := w.CreateValidatorKey()
-	if err != nil {
-		return nil, err
-	}
-
-	// - RUN NEW ROCKETPOOL COMMAND TO GET MINIPOOL WITHDRAWAL CREDENTIALS
-	// - SET WITHDRAWAL_CREDENTIALS := MINIPOOL_WITHDRAWAL_CREDENTIALS
-	// - SET AMOUNT := 16ETH
-	// - DO CHARON DKG -> KEYS ARE CREATED AND CORRECT DEPOSIT_DATA IS GENERATED
-	// - FOR REST OF THE CODE: PUBKEY, SIGNATURE AND DEPOSIT_DATA_ROOT are loaded from disk
-
-	// ROCKETPOOL NODE DEPOSIT --DKG:
-	// - DOESN'T CREATE LOCAL KEY
-	// - USES VALIDATOR KEYS FROM CHARON DKG
-	// - USES PUBKEY, SIGNATURE AND DEPOSIT_DATA_ROOT FROM CHARON DKG DEPOSIT_DATA
-	// ////////////
+	dkg := dkgDepositDataPath != ""
 
 	// Get the next minipool address and withdrawal credentials
 	minipoolAddress, err := utils.GenerateAddress(rp, nodeAccount.Address, depositType, salt, nil, nil)
@@ -400,18 +311,35 @@ func nodeDeposit(c *cli.Context, amountWei *big.Int, minNodeFee float64, salt *b
 	}
 
 	// Get validator deposit data and associated parameters
+	var pubKey rptypes.ValidatorPubkey
+	var signature rptypes.ValidatorSignature
+	var depositDataRoot [32]byte
 	if dkg {
-		// 1. LOAD DEPOSIT_DATA GENERATED BY CHARON DKG.
-		// 2. FOR REST OF THE CODE, USE PUBKEY, SIGNATURE AND DEPOSIT_DATA_ROOT FROM THIS DEPOSIT_DATA.
-	}
+		// Load the Charon-generated deposit_data.json instead of creating a local validator key,
+		// so the BLS secret key for this minipool never touches the node
+		pubKey, signature, depositDataRoot, err = loadDkgDepositData(dkgDepositDataPath, withdrawalCredentials)
+		if err != nil {
+			return nil, fmt.Errorf("error loading DKG deposit data: %w", err)
+		}
 
-	// REST OF THE FLOW CAN CONTINUE AS USUAL.
-	depositData, depositDataRoot, err := validator.GetDepositData(validatorKey, withdrawalCredentials, eth2Config)
-	if err != nil {
-		return nil, err
+		// Track the DKG-provided pubkey so the watcher recognizes this minipool, without ever holding its secret key
+		if err := w.AddValidatorKeyWithoutSecret(pubKey); err != nil {
+			return nil, fmt.Errorf("error saving DKG validator pubkey to wallet: %w", err)
+		}
+	} else {
+		// Create and save a new validator key
+		validatorKey, err := w.CreateValidatorKey()
+		if err != nil {
+			return nil, err
+		}
+		depositData, root, err := validator.GetDepositData(validatorKey, withdrawalCredentials, eth2Config)
+		if err != nil {
+			return nil, err
+		}
+		pubKey = rptypes.BytesToValidatorPubkey(depositData.PublicKey)
+		signature = rptypes.BytesToValidatorSignature(depositData.Signature)
+		depositDataRoot = root
 	}
-	pubKey := rptypes.BytesToValidatorPubkey(depositData.PublicKey)
-	signature := rptypes.BytesToValidatorSignature(depositData.Signature)
 
 	// Make sure a validator with this pubkey doesn't already exist
 	status, err := bc.GetValidatorStatus(pubKey, nil)
@@ -484,6 +412,18 @@ func nodeDeposit(c *cli.Context, amountWei *big.Int, minNodeFee float64, salt *b
 	response.MinipoolAddress = minipoolAddress
 	response.ValidatorPubkey = pubKey
 
+	// Export a canonical deposit_data.json entry for this minipool, for offline signing / DVT tooling
+	cfg, err := services.GetConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	networkName := fmt.Sprint(cfg.Smartnode.Network.Value)
+	depositData, err := buildDepositDataExport(eth2Config, networkName, pubKey, withdrawalCredentials, signature, depositDataRoot)
+	if err != nil {
+		return nil, fmt.Errorf("error building deposit data export: %w", err)
+	}
+	response.DepositData = depositData
+
 	// Return response
 	return &response, nil
 
@@ -509,3 +449,97 @@ func validateDepositInfo(eth2Config beacon.Eth2Config, depositAmount uint64, pub
 	return err
 
 }
+
+// dkgDepositDataEntry mirrors one entry of a standard consensus-layer deposit_data.json,
+// as produced by staking-deposit-cli / Obol / Charon DKG tooling
+type dkgDepositDataEntry struct {
+	Pubkey                string `json:"pubkey"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	Amount                uint64 `json:"amount"`
+	Signature             string `json:"signature"`
+	DepositMessageRoot    string `json:"deposit_message_root"`
+	DepositDataRoot       string `json:"deposit_data_root"`
+	ForkVersion           string `json:"fork_version"`
+	NetworkName           string `json:"network_name"`
+	DepositCliVersion     string `json:"deposit_cli_version"`
+}
+
+// loadDkgDepositData reads a Charon-style deposit_data.json produced by an external DKG ceremony,
+// and checks it against the withdrawal credentials and expected deposit amount of the minipool
+// it's being deposited into. It returns the validator pubkey, signature and deposit data root to deposit with.
+func loadDkgDepositData(path string, withdrawalCredentials common.Hash) (rptypes.ValidatorPubkey, rptypes.ValidatorSignature, [32]byte, error) {
+
+	var pubKey rptypes.ValidatorPubkey
+	var signature rptypes.ValidatorSignature
+	var depositDataRoot [32]byte
+
+	// Read and parse the file
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return pubKey, signature, depositDataRoot, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	var entries []dkgDepositDataEntry
+	if err := json.Unmarshal(bytes, &entries); err != nil {
+		return pubKey, signature, depositDataRoot, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if len(entries) != 1 {
+		return pubKey, signature, depositDataRoot, fmt.Errorf("expected exactly 1 deposit in %s but found %d", path, len(entries))
+	}
+	entry := entries[0]
+
+	// The loaded withdrawal credentials must match the minipool's exactly, or this deposit belongs to a different minipool
+	loadedWithdrawalCredentials, err := decodeHash32(entry.WithdrawalCredentials)
+	if err != nil {
+		return pubKey, signature, depositDataRoot, fmt.Errorf("invalid withdrawal_credentials: %w", err)
+	}
+	if loadedWithdrawalCredentials != withdrawalCredentials {
+		return pubKey, signature, depositDataRoot, fmt.Errorf("withdrawal_credentials in %s (%s) do not match this minipool's withdrawal credentials (%s)", path, common.Hash(loadedWithdrawalCredentials).Hex(), withdrawalCredentials.Hex())
+	}
+
+	// The loaded amount is part of what the signature is computed over, so it must match the
+	// fixed consensus-layer deposit amount validateDepositInfo checks against below - not the
+	// node's bond size, which only determines opts.Value/minipool type for the EL deposit tx
+	expectedAmountGwei := uint64(validator.DepositAmount)
+	if entry.Amount != expectedAmountGwei {
+		return pubKey, signature, depositDataRoot, fmt.Errorf("amount in %s (%d gwei) does not match the expected deposit amount (%d gwei)", path, entry.Amount, expectedAmountGwei)
+	}
+
+	pubkeyBytes, err := decodeHexBytes(entry.Pubkey)
+	if err != nil {
+		return pubKey, signature, depositDataRoot, fmt.Errorf("invalid pubkey: %w", err)
+	}
+	pubKey = rptypes.BytesToValidatorPubkey(pubkeyBytes)
+
+	signatureBytes, err := decodeHexBytes(entry.Signature)
+	if err != nil {
+		return pubKey, signature, depositDataRoot, fmt.Errorf("invalid signature: %w", err)
+	}
+	signature = rptypes.BytesToValidatorSignature(signatureBytes)
+
+	depositDataRoot, err = decodeHash32(entry.DepositDataRoot)
+	if err != nil {
+		return pubKey, signature, depositDataRoot, fmt.Errorf("invalid deposit_data_root: %w", err)
+	}
+
+	return pubKey, signature, depositDataRoot, nil
+
+}
+
+// decodeHexBytes decodes a 0x-prefixed (or bare) hex string into bytes
+func decodeHexBytes(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
+
+// decodeHash32 decodes a 0x-prefixed (or bare) hex string into a fixed 32-byte array
+func decodeHash32(s string) ([32]byte, error) {
+	var out [32]byte
+	b, err := decodeHexBytes(s)
+	if err != nil {
+		return out, err
+	}
+	if len(b) != 32 {
+		return out, fmt.Errorf("expected 32 bytes, got %d", len(b))
+	}
+	copy(out[:], b)
+	return out, nil
+}
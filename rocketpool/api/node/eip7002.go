@@ -0,0 +1,45 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// getWithdrawalRequestFeeAndQueueLength reads the EIP-7002 execution-layer withdrawal request
+// predeploy for the fee that must be forwarded with a request, and the current length of its
+// per-block request queue (so callers can warn the user if a request would be rejected)
+func getWithdrawalRequestFeeAndQueueLength(ec *ethclient.Client) (*big.Int, uint64, error) {
+
+	predeploy := common.HexToAddress(withdrawalRequestPredeployAddress)
+
+	// A zero-data eth_call to the predeploy returns the current fee as a uint256
+	feeBytes, err := ec.CallContract(context.Background(), ethereum.CallMsg{To: &predeploy}, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading EIP-7002 withdrawal request fee: %w", err)
+	}
+	fee := new(big.Int).SetBytes(feeBytes)
+
+	// Slot 0 (excess_withdrawal_requests) is a cumulative counter used only to price the fee above -
+	// it's drained by just TARGET_WITHDRAWAL_REQUESTS_PER_BLOCK (2) per block and is routinely far
+	// larger than the queue's actual 16-per-block cap. The bounded dequeue queue itself is tracked
+	// by the head/tail pointers in slots 2 and 3; its length is tail - head.
+	headBytes, err := ec.StorageAt(context.Background(), predeploy, common.BigToHash(big.NewInt(2)), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading EIP-7002 withdrawal request queue head: %w", err)
+	}
+	tailBytes, err := ec.StorageAt(context.Background(), predeploy, common.BigToHash(big.NewInt(3)), nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading EIP-7002 withdrawal request queue tail: %w", err)
+	}
+	head := new(big.Int).SetBytes(headBytes)
+	tail := new(big.Int).SetBytes(tailBytes)
+	queueLength := new(big.Int).Sub(tail, head).Uint64()
+
+	return fee, queueLength, nil
+
+}
@@ -0,0 +1,114 @@
+package node
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/urfave/cli"
+
+	"github.com/rocket-pool/smartnode/shared/services"
+	"github.com/rocket-pool/smartnode/shared/types/api"
+)
+
+// withdrawalRequestPredeployAddress is the EIP-7002 execution-layer withdrawal request predeploy
+const withdrawalRequestPredeployAddress = "0x00000961Ef480Eb55e80D19ad83579A64c007002"
+
+// withdrawalRequestQueueLimit is the predeploy's hard cap on queued requests per block, per EIP-7002
+const withdrawalRequestQueueLimit = 16
+
+func canExitValidator(c *cli.Context, minipoolAddress common.Address) (*api.CanExitValidatorResponse, error) {
+
+	// Get services
+	if err := services.RequireNodeRegistered(c); err != nil {
+		return nil, err
+	}
+	rp, err := services.GetRocketPool(c)
+	if err != nil {
+		return nil, err
+	}
+	ec, err := services.GetEthClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the current withdrawal request fee and queue length from the EIP-7002 predeploy
+	fee, queueLength, err := getWithdrawalRequestFeeAndQueueLength(ec)
+	if err != nil {
+		return nil, err
+	}
+
+	response := api.CanExitValidatorResponse{}
+	response.Fee = fee
+	response.QueueLength = queueLength
+	response.QueueFull = (queueLength >= withdrawalRequestQueueLimit)
+	response.CanExit = !response.QueueFull
+
+	// Get the gas estimate for the exit request
+	if response.CanExit {
+		w, err := services.GetWallet(c)
+		if err != nil {
+			return nil, err
+		}
+		opts, err := w.GetNodeAccountTransactor()
+		if err != nil {
+			return nil, err
+		}
+		opts.Value = fee
+		gasInfo, err := minipool.EstimateRequestExitGas(rp, minipoolAddress, big.NewInt(0), opts)
+		if err != nil {
+			return nil, err
+		}
+		response.GasInfo = gasInfo
+	}
+
+	return &response, nil
+
+}
+
+func exitValidator(c *cli.Context, minipoolAddress common.Address) (*api.ExitValidatorResponse, error) {
+
+	// Get services
+	if err := services.RequireNodeRegistered(c); err != nil {
+		return nil, err
+	}
+	w, err := services.GetWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := services.GetRocketPool(c)
+	if err != nil {
+		return nil, err
+	}
+	ec, err := services.GetEthClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the current withdrawal request fee
+	fee, _, err := getWithdrawalRequestFeeAndQueueLength(ec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get transactor - the request must originate from the minipool itself, since that's the
+	// address recorded as the validator's withdrawal credentials; the minipool delegate forwards
+	// the call with the required fee on the node's behalf
+	opts, err := w.GetNodeAccountTransactor()
+	if err != nil {
+		return nil, err
+	}
+	opts.Value = fee
+
+	// A zero amount requests a full exit rather than a partial withdrawal
+	tx, err := minipool.RequestExit(rp, minipoolAddress, big.NewInt(0), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	response := api.ExitValidatorResponse{}
+	response.TxHash = tx.Hash()
+	response.Fee = fee
+	return &response, nil
+
+}
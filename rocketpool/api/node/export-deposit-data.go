@@ -0,0 +1,131 @@
+package node
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethpb "github.com/prysmaticlabs/prysm/v3/proto/prysm/v1alpha1"
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	rptypes "github.com/rocket-pool/rocketpool-go/types"
+	"github.com/urfave/cli"
+
+	"github.com/rocket-pool/smartnode/shared/services"
+	"github.com/rocket-pool/smartnode/shared/services/beacon"
+	"github.com/rocket-pool/smartnode/shared/types/api"
+	"github.com/rocket-pool/smartnode/shared/utils/validator"
+)
+
+// depositCliVersion is reported in exported deposit_data.json so downstream tooling
+// (staking-deposit-cli / Obol / SSV) recognizes the artifact's schema version
+const depositCliVersion = "2.3.0"
+
+// buildDepositDataExport assembles a standard consensus-layer deposit_data.json entry
+// for a minipool, for use with staking-deposit-cli-compatible tooling and offline signing workflows
+func buildDepositDataExport(eth2Config beacon.Eth2Config, networkName string, pubKey rptypes.ValidatorPubkey, withdrawalCredentials common.Hash, signature rptypes.ValidatorSignature, depositDataRoot [32]byte) (api.DepositDataExport, error) {
+
+	// The amount is part of what the signature is computed over, so it must be the fixed
+	// consensus-layer deposit amount validateDepositInfo checks against - not the node's bond size,
+	// which is independent of it (see validator.GetDepositData / loadDkgDepositData)
+	amountGwei := uint64(validator.DepositAmount)
+
+	// Compute the deposit message root (pubkey, withdrawal_credentials, amount - no signature)
+	depositMessage := &ethpb.DepositMessage{
+		PublicKey:             pubKey.Bytes(),
+		WithdrawalCredentials: withdrawalCredentials.Bytes(),
+		Amount:                amountGwei,
+	}
+	depositMessageRoot, err := depositMessage.HashTreeRoot()
+	if err != nil {
+		return api.DepositDataExport{}, fmt.Errorf("error computing deposit message root: %w", err)
+	}
+
+	return api.DepositDataExport{
+		Pubkey:                "0x" + hex.EncodeToString(pubKey.Bytes()),
+		WithdrawalCredentials: "0x" + hex.EncodeToString(withdrawalCredentials.Bytes()),
+		Amount:                amountGwei,
+		Signature:             "0x" + hex.EncodeToString(signature.Bytes()),
+		DepositMessageRoot:    "0x" + hex.EncodeToString(depositMessageRoot[:]),
+		DepositDataRoot:       "0x" + hex.EncodeToString(depositDataRoot[:]),
+		ForkVersion:           "0x" + hex.EncodeToString(eth2Config.GenesisForkVersion),
+		NetworkName:           networkName,
+		DepositCliVersion:     depositCliVersion,
+	}, nil
+
+}
+
+// exportDepositData reconstructs and returns the canonical deposit_data.json entry for an
+// already-created minipool, for operators who need the artifact after the fact (e.g. offline
+// signing workflows where the original `node deposit` response wasn't captured)
+func exportDepositData(c *cli.Context, minipoolAddress common.Address) (*api.ExportDepositDataResponse, error) {
+
+	// Get services
+	if err := services.RequireNodeRegistered(c); err != nil {
+		return nil, err
+	}
+	rp, err := services.GetRocketPool(c)
+	if err != nil {
+		return nil, err
+	}
+	bc, err := services.GetBeaconClient(c)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := services.GetConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	w, err := services.GetWallet(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get eth2 config
+	eth2Config, err := bc.GetEth2Config()
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the minipool's on-chain withdrawal credentials
+	withdrawalCredentials, err := minipool.GetMinipoolWithdrawalCredentials(rp, minipoolAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Minipools deposited via --dkg (see node deposit --dkg) never have a local secret key by design -
+	// fail clearly here instead of letting GetValidatorKeyForMinipool surface a low-level wallet error
+	pubkey, err := minipool.GetMinipoolPubkey(rp, minipoolAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	hasSecret, err := w.HasValidatorSecretKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	if !hasSecret {
+		return nil, fmt.Errorf("minipool %s was created with `node deposit --dkg`; this node never held its validator secret key, so its deposit_data.json can't be re-exported here - re-export it from your DKG ceremony's original deposit_data.json instead", minipoolAddress.Hex())
+	}
+
+	// Get the validator key this minipool was deposited with, and re-derive its signature
+	validatorKey, err := w.GetValidatorKeyForMinipool(minipoolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validator key for minipool %s: %w", minipoolAddress.Hex(), err)
+	}
+	depositData, depositDataRoot, err := validator.GetDepositData(validatorKey, withdrawalCredentials, eth2Config)
+	if err != nil {
+		return nil, err
+	}
+	pubKey := rptypes.BytesToValidatorPubkey(depositData.PublicKey)
+	signature := rptypes.BytesToValidatorSignature(depositData.Signature)
+
+	networkName := fmt.Sprint(cfg.Smartnode.Network.Value)
+	export, err := buildDepositDataExport(eth2Config, networkName, pubKey, withdrawalCredentials, signature, depositDataRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	response := api.ExportDepositDataResponse{}
+	response.DepositData = export
+	return &response, nil
+
+}
@@ -0,0 +1,165 @@
+package node
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/urfave/cli"
+
+	"github.com/rocket-pool/smartnode/shared/services"
+	"github.com/rocket-pool/smartnode/shared/types/api"
+)
+
+// validatorActivationBalanceWei is the 32 ETH floor a validator's balance must stay above
+// when skimming rewards via a partial withdrawal request
+var validatorActivationBalanceWei = new(big.Int).Mul(big.NewInt(32000000000), big.NewInt(1e9))
+
+func canPartialWithdraw(c *cli.Context, minipoolAddress common.Address, amountGwei uint64) (*api.CanPartialWithdrawResponse, error) {
+
+	// Get services
+	if err := services.RequireNodeRegistered(c); err != nil {
+		return nil, err
+	}
+	w, err := services.GetWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := services.GetRocketPool(c)
+	if err != nil {
+		return nil, err
+	}
+	bc, err := services.GetBeaconClient(c)
+	if err != nil {
+		return nil, err
+	}
+	ec, err := services.GetEthClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	response := api.CanPartialWithdrawResponse{}
+	amountWei := new(big.Int).Mul(new(big.Int).SetUint64(amountGwei), big.NewInt(1e9))
+
+	// Get the validator's current balance
+	pubkey, err := minipool.GetMinipoolPubkey(rp, minipoolAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	status, err := bc.GetValidatorStatus(pubkey, nil)
+	if err != nil {
+		return nil, err
+	}
+	balanceWei := new(big.Int).Mul(new(big.Int).SetUint64(status.Balance), big.NewInt(1e9))
+
+	// Reject withdrawals that would push the validator below the 32 ETH activation floor
+	remainingBalanceWei := new(big.Int).Sub(balanceWei, amountWei)
+	response.BelowActivationFloor = (remainingBalanceWei.Cmp(validatorActivationBalanceWei) < 0)
+
+	// Get the current withdrawal request fee and queue length
+	fee, queueLength, err := getWithdrawalRequestFeeAndQueueLength(ec)
+	if err != nil {
+		return nil, err
+	}
+	response.Fee = fee
+	response.QueueLength = queueLength
+	response.QueueFull = (queueLength >= withdrawalRequestQueueLimit)
+
+	// Project the node-vs-pool split of the skimmed amount based on this minipool's bond size and node fee
+	nodeFee, err := minipool.GetMinipoolNodeFee(rp, minipoolAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	bondAmountWei, err := minipool.GetMinipoolNodeDepositBalance(rp, minipoolAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	response.NodeAmount, response.PoolAmount = calculateRewardSplit(amountWei, bondAmountWei, nodeFee)
+
+	response.CanWithdraw = !(response.BelowActivationFloor || response.QueueFull)
+
+	// Get the gas estimate for the withdrawal request
+	if response.CanWithdraw {
+		opts, err := w.GetNodeAccountTransactor()
+		if err != nil {
+			return nil, err
+		}
+		opts.Value = fee
+		gasInfo, err := minipool.EstimateRequestExitGas(rp, minipoolAddress, new(big.Int).SetUint64(amountGwei), opts)
+		if err != nil {
+			return nil, err
+		}
+		response.GasInfo = gasInfo
+	}
+
+	return &response, nil
+
+}
+
+func nodePartialWithdraw(c *cli.Context, minipoolAddress common.Address, amountGwei uint64) (*api.PartialWithdrawResponse, error) {
+
+	// Get services
+	if err := services.RequireNodeRegistered(c); err != nil {
+		return nil, err
+	}
+	w, err := services.GetWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := services.GetRocketPool(c)
+	if err != nil {
+		return nil, err
+	}
+	ec, err := services.GetEthClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the current withdrawal request fee
+	fee, _, err := getWithdrawalRequestFeeAndQueueLength(ec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get transactor - the request must originate from the minipool itself
+	opts, err := w.GetNodeAccountTransactor()
+	if err != nil {
+		return nil, err
+	}
+	opts.Value = fee
+
+	// A nonzero amount requests a partial withdrawal (skim) rather than a full exit
+	tx, err := minipool.RequestExit(rp, minipoolAddress, new(big.Int).SetUint64(amountGwei), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	response := api.PartialWithdrawResponse{}
+	response.TxHash = tx.Hash()
+	response.Fee = fee
+	return &response, nil
+
+}
+
+// calculateRewardSplit mirrors the protocol's reward-distribution formula: the node earns its
+// bond-proportional share of the skimmed amount, plus its commission on the pool's remaining share
+func calculateRewardSplit(amountWei *big.Int, bondAmountWei *big.Int, nodeFee float64) (*big.Int, *big.Int) {
+
+	// The node's bond-proportional share: amount * bond / 32 ETH
+	bondShareWei := new(big.Int).Div(new(big.Int).Mul(amountWei, bondAmountWei), validatorActivationBalanceWei)
+
+	// The rest belongs to the pool before the node's commission is taken
+	poolShareBeforeFeeWei := new(big.Int).Sub(amountWei, bondShareWei)
+
+	// The node additionally earns its commission on the pool's share
+	nodeFeeWei := new(big.Int).Div(
+		new(big.Int).Mul(poolShareBeforeFeeWei, big.NewInt(int64(nodeFee*1e18))),
+		big.NewInt(1e18),
+	)
+
+	nodeAmountWei := new(big.Int).Add(bondShareWei, nodeFeeWei)
+	poolAmountWei := new(big.Int).Sub(amountWei, nodeAmountWei)
+
+	return nodeAmountWei, poolAmountWei
+
+}
@@ -0,0 +1,74 @@
+package node
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/node"
+	"github.com/rocket-pool/rocketpool-go/utils"
+	"github.com/urfave/cli"
+
+	"github.com/rocket-pool/smartnode/shared/services"
+	"github.com/rocket-pool/smartnode/shared/types/api"
+)
+
+// getNodeWithdrawalCredentials computes the deterministic minipool address and withdrawal credentials
+// for the node's next deposit, without creating anything on-chain. Operators feed these into a Charon
+// DKG ceremony ahead of `rocketpool node deposit --dkg`.
+func getNodeWithdrawalCredentials(c *cli.Context, amountWei *big.Int, salt *big.Int) (*api.NodeWithdrawalCredentialsResponse, error) {
+
+	// Get services
+	if err := services.RequireNodeRegistered(c); err != nil {
+		return nil, err
+	}
+	w, err := services.GetWallet(c)
+	if err != nil {
+		return nil, err
+	}
+	ec, err := services.GetEthClient(c)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := services.GetRocketPool(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get node account
+	nodeAccount, err := w.GetNodeAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	// Adjust the salt
+	if salt.Cmp(big.NewInt(0)) == 0 {
+		nonce, err := ec.NonceAt(context.Background(), nodeAccount.Address, nil)
+		if err != nil {
+			return nil, err
+		}
+		salt.SetUint64(nonce)
+	}
+
+	// Get the deposit type
+	depositType, err := node.GetDepositType(rp, amountWei, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the next minipool address and withdrawal credentials
+	minipoolAddress, err := utils.GenerateAddress(rp, nodeAccount.Address, depositType, salt, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	withdrawalCredentials, err := minipool.GetMinipoolWithdrawalCredentials(rp, minipoolAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response := api.NodeWithdrawalCredentialsResponse{}
+	response.MinipoolAddress = minipoolAddress
+	response.WithdrawalCredentials = withdrawalCredentials
+	return &response, nil
+
+}